@@ -0,0 +1,31 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package assert contains small test helpers shared across internal
+// packages' unit tests.
+package assert
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Equal fails the test with a diff if got and want are not equal.
+func Equal(t *testing.T, got, want interface{}) {
+	t.Helper()
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("mismatch: (-want, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	networkingv1listers "k8s.io/client-go/listers/networking/v1"
+)
+
+const (
+	ingressClassAnnotation          = "kubernetes.io/ingress.class"
+	isDefaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+
+	// DefaultIngressClassControllerName is the controller string Contour
+	// looks for on a networking.k8s.io/v1 IngressClass when an explicit
+	// controller name is not configured.
+	DefaultIngressClassControllerName = "projectcontour.io/ingress-controller"
+)
+
+// ingressClassControllerName returns configured, or
+// DefaultIngressClassControllerName if it is empty.
+func ingressClassControllerName(configured string) string {
+	if configured != "" {
+		return configured
+	}
+
+	return DefaultIngressClassControllerName
+}
+
+// ingressMatchesClass reports whether ing belongs to us. If it carries
+// the "kubernetes.io/ingress.class" annotation, that is decisive: it
+// matches only if the annotation equals ingressClass, even when
+// ingressClass is itself empty. Otherwise, its spec.ingressClassName, if
+// set, names an IngressClass (resolved via lister) whose spec.controller
+// must equal controllerName. Only when ing carries neither the
+// annotation nor spec.ingressClassName do we fall back to: ingressClass
+// unset entirely (the long-standing "no ingress class configured, match
+// everything" default), or an IngressClass annotated is-default-class
+// exists whose spec.controller equals controllerName.
+func ingressMatchesClass(ing *v1beta1.Ingress, ingressClass, controllerName string, lister networkingv1listers.IngressClassLister) bool {
+	annotationClass := ing.Annotations[ingressClassAnnotation]
+	if annotationClass != "" {
+		return annotationClass == ingressClass
+	}
+
+	if name := ing.Spec.IngressClassName; name != nil && *name != "" {
+		return ingressClassNameMatchesController(lister, *name, controllerName)
+	}
+
+	if ingressClass == "" {
+		return true
+	}
+
+	return hasMatchingDefaultIngressClass(lister, controllerName)
+}
+
+// ingressClassNameMatchesController resolves name via lister and
+// reports whether its spec.controller matches controllerName.
+func ingressClassNameMatchesController(lister networkingv1listers.IngressClassLister, name, controllerName string) bool {
+	if lister == nil {
+		return false
+	}
+
+	ic, err := lister.Get(name)
+	if err != nil {
+		return false
+	}
+
+	return ic.Spec.Controller == controllerName
+}
+
+// hasMatchingDefaultIngressClass reports whether any IngressClass known
+// to lister is marked the cluster default and has a spec.controller
+// matching controllerName.
+func hasMatchingDefaultIngressClass(lister networkingv1listers.IngressClassLister, controllerName string) bool {
+	if lister == nil {
+		return false
+	}
+
+	classes, err := lister.List(labels.Everything())
+	if err != nil {
+		return false
+	}
+
+	for _, ic := range classes {
+		if ic.Annotations[isDefaultIngressClassAnnotation] == "true" && ic.Spec.Controller == controllerName {
+			return true
+		}
+	}
+
+	return false
+}
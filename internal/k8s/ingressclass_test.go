@@ -0,0 +1,146 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	networkingv1listers "k8s.io/client-go/listers/networking/v1"
+)
+
+func newIngressClassLister(t *testing.T, classes ...*networkingv1.IngressClass) networkingv1listers.IngressClassLister {
+	t.Helper()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ic := range classes {
+		if err := indexer.Add(ic); err != nil {
+			t.Fatalf("unable to seed IngressClass indexer: %v", err)
+		}
+	}
+
+	return networkingv1listers.NewIngressClassLister(indexer)
+}
+
+func TestStatusAddressUpdaterMatchesIngressClassResource(t *testing.T) {
+	log := logrus.New()
+
+	ourController := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "ours"},
+		Spec:       networkingv1.IngressClassSpec{Controller: DefaultIngressClassControllerName},
+	}
+
+	defaultOurController := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ours-default",
+			Annotations: map[string]string{isDefaultIngressClassAnnotation: "true"},
+		},
+		Spec: networkingv1.IngressClassSpec{Controller: DefaultIngressClassControllerName},
+	}
+
+	defaultOtherController := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "other-default",
+			Annotations: map[string]string{isDefaultIngressClassAnnotation: "true"},
+		},
+		Spec: networkingv1.IngressClassSpec{Controller: "example.io/other-controller"},
+	}
+
+	otherController := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Spec:       networkingv1.IngressClassSpec{Controller: "example.io/other-controller"},
+	}
+
+	strptr := func(s string) *string { return &s }
+
+	tests := map[string]struct {
+		ingressClass     *string // nil defaults to "phony"
+		annotation       string
+		ingressClassName *string
+		lister           networkingv1listers.IngressClassLister
+		want             bool
+	}{
+		"annotation matches, no spec.ingressClassName": {
+			annotation: "phony",
+			want:       true,
+		},
+		"annotation set but does not match, spec.ingressClassName unset, no default class": {
+			annotation: "other",
+			lister:     newIngressClassLister(t),
+			want:       false,
+		},
+		"annotation set but does not match, spec.ingressClassName unset, default class present": {
+			annotation: "nginx",
+			lister:     newIngressClassLister(t, defaultOurController),
+			want:       false,
+		},
+		"no annotation, spec.ingressClassName resolves to our controller": {
+			ingressClassName: strptr("ours"),
+			lister:           newIngressClassLister(t, ourController),
+			want:             true,
+		},
+		"no annotation, spec.ingressClassName resolves to a different controller": {
+			ingressClassName: strptr("other"),
+			lister:           newIngressClassLister(t, otherController),
+			want:             false,
+		},
+		"no annotation, no spec.ingressClassName, default class matches our controller": {
+			lister: newIngressClassLister(t, defaultOurController),
+			want:   true,
+		},
+		"no annotation, no spec.ingressClassName, default class matches a different controller": {
+			lister: newIngressClassLister(t, defaultOtherController),
+			want:   false,
+		},
+		"no ingress class configured anywhere, plain ingress with no annotation": {
+			ingressClass: strptr(""),
+			lister:       newIngressClassLister(t),
+			want:         true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ing := &v1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+			}
+			if tc.annotation != "" {
+				ing.Annotations = map[string]string{ingressClassAnnotation: tc.annotation}
+			}
+			ing.Spec.IngressClassName = tc.ingressClassName
+
+			ingressClass := "phony"
+			if tc.ingressClass != nil {
+				ingressClass = *tc.ingressClass
+			}
+
+			isu := StatusAddressUpdater{
+				Logger:             log,
+				IngressClass:       ingressClass,
+				IngressClassLister: tc.lister,
+				LBStatus:           v1.LoadBalancerStatus{},
+			}
+
+			if got := isu.matchesIngressClass(ing); got != tc.want {
+				t.Fatalf("matchesIngressClass() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
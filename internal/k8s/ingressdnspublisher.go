@@ -0,0 +1,228 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+	networkingv1listers "k8s.io/client-go/listers/networking/v1"
+)
+
+// dnsRecord holds the A/AAAA/CNAME records published for a single host.
+type dnsRecord struct {
+	A     []string `json:"a"`
+	AAAA  []string `json:"aaaa"`
+	CNAME []string `json:"cname"`
+}
+
+// dnsRecords is the payload written into the target ConfigMap's data
+// key, one entry per hostname with at least one populated Ingress rule.
+type dnsRecords struct {
+	Records map[string]dnsRecord `json:"records"`
+}
+
+// IngressDNSPublisher watches Ingress objects alongside a LBStatus
+// channel (fed by a ServiceStatusLoadBalancerWatcher, or a static
+// address list) and materializes an A/AAAA/CNAME record for every rule
+// host of every matching Ingress whose status.loadBalancer has been
+// populated, into a designated ConfigMap. This lets an in-cluster
+// nameserver answer for Contour-managed hostnames without delegation
+// from upstream DNS.
+type IngressDNSPublisher struct {
+	Logger                     logrus.FieldLogger
+	Client                     kubernetes.Interface
+	LBStatus                   chan v1.LoadBalancerStatus
+	ConfigMapNamespace         string
+	ConfigMapName              string
+	ConfigMapKey               string
+	IngressClass               string
+	IngressClassControllerName string
+	IngressClassLister         networkingv1listers.IngressClassLister
+
+	mu        sync.Mutex
+	ingresses map[string]*v1beta1.Ingress
+}
+
+// Start blocks, consuming LBStatus until it is closed, rebuilding the
+// ConfigMap every time it fires. Each matching Ingress's own
+// status.loadBalancer (set independently by a StatusAddressUpdater) is
+// what actually feeds the published records; a LBStatus notification
+// here just means that status is now worth re-sweeping for. Run this in
+// its own goroutine.
+func (p *IngressDNSPublisher) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-p.LBStatus:
+			if !ok {
+				return
+			}
+
+			p.rebuild(ctx)
+		}
+	}
+}
+
+// OnAdd implements ResourceEventHandler.
+func (p *IngressDNSPublisher) OnAdd(obj interface{}) {
+	p.upsert(obj)
+}
+
+// OnUpdate implements ResourceEventHandler.
+func (p *IngressDNSPublisher) OnUpdate(oldObj, newObj interface{}) {
+	p.upsert(newObj)
+}
+
+// OnDelete implements ResourceEventHandler.
+func (p *IngressDNSPublisher) OnDelete(obj interface{}) {
+	ing, ok := obj.(*v1beta1.Ingress)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	delete(p.ingresses, key(ing))
+	p.mu.Unlock()
+
+	p.rebuild(context.Background())
+}
+
+func (p *IngressDNSPublisher) upsert(obj interface{}) {
+	ing, ok := obj.(*v1beta1.Ingress)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	if p.ingresses == nil {
+		p.ingresses = make(map[string]*v1beta1.Ingress)
+	}
+	p.ingresses[key(ing)] = ing
+	p.mu.Unlock()
+
+	p.rebuild(context.Background())
+}
+
+func key(ing *v1beta1.Ingress) string {
+	return ing.Namespace + "/" + ing.Name
+}
+
+func (p *IngressDNSPublisher) controllerName() string {
+	return ingressClassControllerName(p.IngressClassControllerName)
+}
+
+func (p *IngressDNSPublisher) dataKey() string {
+	if p.ConfigMapKey != "" {
+		return p.ConfigMapKey
+	}
+
+	return "records"
+}
+
+// rebuild performs a full sweep of the cached Ingresses and writes the
+// resulting record set to the ConfigMap, if it differs from what is
+// already there.
+func (p *IngressDNSPublisher) rebuild(ctx context.Context) {
+	p.mu.Lock()
+	records := dnsRecords{Records: map[string]dnsRecord{}}
+	for _, ing := range p.ingresses {
+		if !ingressMatchesClass(ing, p.IngressClass, p.controllerName(), p.IngressClassLister) {
+			continue
+		}
+
+		for _, lbi := range ing.Status.LoadBalancer.Ingress {
+			for _, rule := range ing.Spec.Rules {
+				if rule.Host == "" {
+					continue
+				}
+
+				rec := records.Records[rule.Host]
+				if ip := net.ParseIP(lbi.IP); ip != nil {
+					if ip.To4() != nil {
+						rec.A = append(rec.A, lbi.IP)
+					} else {
+						rec.AAAA = append(rec.AAAA, lbi.IP)
+					}
+				}
+				if lbi.Hostname != "" {
+					rec.CNAME = append(rec.CNAME, lbi.Hostname)
+				}
+				records.Records[rule.Host] = rec
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	for host, rec := range records.Records {
+		sort.Strings(rec.A)
+		sort.Strings(rec.AAAA)
+		sort.Strings(rec.CNAME)
+		records.Records[host] = rec
+	}
+
+	payload, err := json.Marshal(records)
+	if err != nil {
+		p.Logger.WithError(err).Error("failed to marshal DNS records")
+		return
+	}
+
+	if err := p.publish(ctx, string(payload)); err != nil {
+		p.Logger.WithError(err).Error("failed to publish DNS records ConfigMap")
+	}
+}
+
+// publish strategic-merge patches the target ConfigMap's data key to
+// payload, creating no new document shape beyond the single key it owns.
+func (p *IngressDNSPublisher) publish(ctx context.Context, payload string) error {
+	client := p.Client.CoreV1().ConfigMaps(p.ConfigMapNamespace)
+
+	old, err := client.Get(ctx, p.ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if old.Data[p.dataKey()] == payload {
+		return nil
+	}
+
+	oldData, err := json.Marshal(v1.ConfigMap{Data: map[string]string{p.dataKey(): old.Data[p.dataKey()]}})
+	if err != nil {
+		return err
+	}
+
+	newData, err := json.Marshal(v1.ConfigMap{Data: map[string]string{p.dataKey(): payload}})
+	if err != nil {
+		return err
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, v1.ConfigMap{})
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Patch(ctx, p.ConfigMapName, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,104 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func ingressWithHostAndLB(name, host string, lb v1.LoadBalancerStatus) *v1beta1.Ingress {
+	return &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{Host: host}},
+		},
+		Status: v1beta1.IngressStatus{LoadBalancer: lb},
+	}
+}
+
+func TestIngressDNSPublisherRebuild(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns", Namespace: "projectcontour"},
+	})
+
+	log := logrus.New()
+
+	p := &IngressDNSPublisher{
+		Logger:             log,
+		Client:             client,
+		ConfigMapNamespace: "projectcontour",
+		ConfigMapName:      "dns",
+	}
+
+	p.upsert(ingressWithHostAndLB("a", "a.projectcontour.io", v1.LoadBalancerStatus{
+		Ingress: []v1.LoadBalancerIngress{{IP: "127.0.0.1"}},
+	}))
+	p.upsert(ingressWithHostAndLB("b", "b.projectcontour.io", v1.LoadBalancerStatus{
+		Ingress: []v1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+	}))
+	p.upsert(ingressWithHostAndLB("c", "c.projectcontour.io", v1.LoadBalancerStatus{
+		Ingress: []v1.LoadBalancerIngress{{IP: "2001:db8::1"}},
+	}))
+
+	cm, err := client.CoreV1().ConfigMaps("projectcontour").Get(context.Background(), "dns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error fetching ConfigMap: %v", err)
+	}
+
+	var got dnsRecords
+	if err := json.Unmarshal([]byte(cm.Data["records"]), &got); err != nil {
+		t.Fatalf("records payload is not valid JSON: %v", err)
+	}
+
+	want := dnsRecords{Records: map[string]dnsRecord{
+		"a.projectcontour.io": {A: []string{"127.0.0.1"}},
+		"b.projectcontour.io": {CNAME: []string{"lb.example.com"}},
+		"c.projectcontour.io": {AAAA: []string{"2001:db8::1"}},
+	}}
+
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Fatalf("records = %s, want %s", gotJSON, wantJSON)
+	}
+
+	// Deleting the only Ingress for a host removes it from the published set.
+	p.OnDelete(ingressWithHostAndLB("a", "a.projectcontour.io", v1.LoadBalancerStatus{}))
+
+	cm, err = client.CoreV1().ConfigMaps("projectcontour").Get(context.Background(), "dns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error fetching ConfigMap: %v", err)
+	}
+
+	got = dnsRecords{}
+	if err := json.Unmarshal([]byte(cm.Data["records"]), &got); err != nil {
+		t.Fatalf("records payload is not valid JSON: %v", err)
+	}
+
+	if _, ok := got.Records["a.projectcontour.io"]; ok {
+		t.Fatalf("expected a.projectcontour.io to be removed after delete, got %v", got.Records)
+	}
+
+	if _, ok := got.Records["b.projectcontour.io"]; !ok {
+		t.Fatalf("expected b.projectcontour.io to remain, got %v", got.Records)
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/projectcontour/contour/internal/status/result"
+)
+
+// StatusMutator describes an interface to mutate an object before it is
+// written to the API server, turning the cached "old" object into the
+// "new" object that should be persisted.
+type StatusMutator interface {
+	Mutate(old interface{}) interface{}
+}
+
+// StatusMutatorFunc is a function adaptor for StatusMutators.
+type StatusMutatorFunc func(interface{}) interface{}
+
+// Mutate adapts the StatusMutatorFunc to fit through the StatusMutator interface.
+func (m StatusMutatorFunc) Mutate(old interface{}) interface{} {
+	if m == nil {
+		return nil
+	}
+
+	return m(old)
+}
+
+// StatusUpdater describes the interface used to update the status of
+// Kubernetes objects. Implementations are expected to fetch the current
+// version of the object named by name/namespace/gvr, apply mutator to it,
+// and write the result back to the API server (or, for testing, a cache).
+// The returned Status lets callers apply differentiated retry policies
+// instead of treating every outcome the same way.
+type StatusUpdater interface {
+	Update(name, namespace string, gvr schema.GroupVersionResource, mutator StatusMutator) result.Status
+}
+
+// cacheKey is the lookup key used by StatusUpdateCacher.
+type cacheKey struct {
+	name      string
+	namespace string
+	gvr       schema.GroupVersionResource
+}
+
+// StatusUpdateCacher takes status updates and applies them to a local
+// cache, instead of talking to the API server. This is used to simplify
+// testing of status update logic.
+type StatusUpdateCacher struct {
+	objectCache map[cacheKey]interface{}
+	patches     map[cacheKey][]byte
+}
+
+// Update applies mutator to the object previously stored under
+// name/namespace/gvr and stores the result back into the cache. The
+// load balancer status patch that a real StatusUpdater would send to
+// the API server is computed (and recorded in LastPatch) so that tests
+// can assert on its shape, but it is never actually applied; the full
+// mutated object always replaces the cache entry.
+func (suc *StatusUpdateCacher) Update(name, namespace string, gvr schema.GroupVersionResource, mutator StatusMutator) result.Status {
+	if suc.objectCache == nil {
+		suc.objectCache = make(map[cacheKey]interface{})
+	}
+
+	key := cacheKey{name: name, namespace: namespace, gvr: gvr}
+	old, ok := suc.objectCache[key]
+	if !ok {
+		return result.NotApplicable
+	}
+
+	new := mutator.Mutate(old)
+	status := result.Success
+
+	if oldLB, ok := lbStatusOf(old); ok {
+		if newLB, ok := lbStatusOf(new); ok {
+			if LoadBalancerStatusEqual(oldLB, newLB) {
+				status = result.NoChange
+			}
+
+			_ = PatchLoadBalancerStatus(oldLB, newLB, func(pt types.PatchType, data []byte) error {
+				suc.recordPatch(key, data)
+				return nil
+			})
+		}
+	}
+
+	suc.objectCache[key] = new
+	return status
+}
+
+// recordPatch stores the most recent patch body generated for key, for
+// tests to inspect via LastPatch.
+func (suc *StatusUpdateCacher) recordPatch(key cacheKey, data []byte) {
+	if suc.patches == nil {
+		suc.patches = make(map[cacheKey][]byte)
+	}
+
+	suc.patches[key] = data
+}
+
+// LastPatch returns the patch body most recently computed for
+// name/namespace/gvr, or nil if none has been recorded.
+func (suc *StatusUpdateCacher) LastPatch(name, namespace string, gvr schema.GroupVersionResource) []byte {
+	return suc.patches[cacheKey{name: name, namespace: namespace, gvr: gvr}]
+}
+
+// AddObject seeds the cache with obj under name/namespace/gvr. It returns
+// false if an object is already cached under that key.
+func (suc *StatusUpdateCacher) AddObject(name, namespace string, gvr schema.GroupVersionResource, obj interface{}) bool {
+	if suc.objectCache == nil {
+		suc.objectCache = make(map[cacheKey]interface{})
+	}
+
+	key := cacheKey{name: name, namespace: namespace, gvr: gvr}
+	if _, ok := suc.objectCache[key]; ok {
+		return false
+	}
+
+	suc.objectCache[key] = obj
+	return true
+}
+
+// GetObject returns the object cached under name/namespace/gvr, or nil.
+func (suc *StatusUpdateCacher) GetObject(name, namespace string, gvr schema.GroupVersionResource) interface{} {
+	if suc.objectCache == nil {
+		return nil
+	}
+
+	return suc.objectCache[cacheKey{name: name, namespace: namespace, gvr: gvr}]
+}
+
+// LoadBalancerStatusEqual compares two LoadBalancerStatus values for
+// equality, ignoring ordering of the Ingress slice: a LB or
+// --publish-address list that republishes the same addresses in a
+// different order is not a change worth patching or re-syncing.
+func LoadBalancerStatusEqual(l, r v1.LoadBalancerStatus) bool {
+	if len(l.Ingress) != len(r.Ingress) {
+		return false
+	}
+
+	lc := sortedLoadBalancerIngress(l.Ingress)
+	rc := sortedLoadBalancerIngress(r.Ingress)
+
+	return reflect.DeepEqual(lc, rc)
+}
+
+// sortedLoadBalancerIngress returns a copy of ingress sorted by
+// IP, then Hostname, so two semantically equal but differently ordered
+// slices compare equal.
+func sortedLoadBalancerIngress(ingress []v1.LoadBalancerIngress) []v1.LoadBalancerIngress {
+	sorted := append([]v1.LoadBalancerIngress(nil), ingress...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].IP != sorted[j].IP {
+			return sorted[i].IP < sorted[j].IP
+		}
+
+		return sorted[i].Hostname < sorted[j].Hostname
+	})
+
+	return sorted
+}
+
+// IsStatusEqual compares the status of two objects of the same type,
+// returning true if they are equal. Only *v1beta1.Ingress and *v1.Service
+// are supported; any other type (or a type mismatch) returns false.
+func IsStatusEqual(old, new interface{}) bool {
+	switch a := old.(type) {
+	case *v1beta1.Ingress:
+		b, ok := new.(*v1beta1.Ingress)
+		return ok && LoadBalancerStatusEqual(a.Status.LoadBalancer, b.Status.LoadBalancer)
+	case *v1.Service:
+		b, ok := new.(*v1.Service)
+		return ok && LoadBalancerStatusEqual(a.Status.LoadBalancer, b.Status.LoadBalancer)
+	default:
+		return false
+	}
+}
+
+// lbStatusOf extracts the LoadBalancerStatus from a *v1beta1.Ingress or
+// *v1.Service, returning false for any other (or nil) type.
+func lbStatusOf(obj interface{}) (v1.LoadBalancerStatus, bool) {
+	switch o := obj.(type) {
+	case *v1beta1.Ingress:
+		return o.Status.LoadBalancer, true
+	case *v1.Service:
+		return o.Status.LoadBalancer, true
+	default:
+		return v1.LoadBalancerStatus{}, false
+	}
+}
+
+// StatusUpdateHandler is the production StatusUpdater: it fetches the
+// current object from the API server, applies mutator, and writes the
+// result back with a minimal status-only Patch rather than a full
+// Update, so that it does not clobber concurrent changes to other
+// fields or lose to resourceVersion conflicts.
+type StatusUpdateHandler struct {
+	Log       logrus.FieldLogger
+	Clientset kubernetes.Interface
+}
+
+// Update implements StatusUpdater.
+func (suh *StatusUpdateHandler) Update(name, namespace string, gvr schema.GroupVersionResource, mutator StatusMutator) result.Status {
+	ctx := context.Background()
+	log := suh.Log.WithField("name", name).WithField("namespace", namespace)
+
+	switch gvr {
+	case ingressGVR:
+		return suh.updateIngress(ctx, log, name, namespace, mutator)
+	case serviceGVR:
+		return suh.updateService(ctx, log, name, namespace, mutator)
+	default:
+		log.WithField("gvr", gvr).Error("unsupported GroupVersionResource for status update")
+		return result.NotApplicable
+	}
+}
+
+func (suh *StatusUpdateHandler) updateIngress(ctx context.Context, log logrus.FieldLogger, name, namespace string, mutator StatusMutator) result.Status {
+	client := suh.Clientset.NetworkingV1beta1().Ingresses(namespace)
+
+	old, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		log.WithError(err).Error("failed to get Ingress")
+		return result.ClassifyError(err)
+	}
+
+	newObj, ok := mutator.Mutate(old).(*v1beta1.Ingress)
+	if !ok {
+		log.Error("mutator did not return an *v1beta1.Ingress")
+		return result.PermanentError
+	}
+
+	if LoadBalancerStatusEqual(old.Status.LoadBalancer, newObj.Status.LoadBalancer) {
+		return result.NoChange
+	}
+
+	err = PatchLoadBalancerStatus(old.Status.LoadBalancer, newObj.Status.LoadBalancer, func(pt types.PatchType, data []byte) error {
+		_, err := client.Patch(ctx, name, pt, data, metav1.PatchOptions{}, "status")
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to patch Ingress status")
+	}
+
+	return result.ClassifyError(err)
+}
+
+func (suh *StatusUpdateHandler) updateService(ctx context.Context, log logrus.FieldLogger, name, namespace string, mutator StatusMutator) result.Status {
+	client := suh.Clientset.CoreV1().Services(namespace)
+
+	old, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		log.WithError(err).Error("failed to get Service")
+		return result.ClassifyError(err)
+	}
+
+	newObj, ok := mutator.Mutate(old).(*v1.Service)
+	if !ok {
+		log.Error("mutator did not return a *v1.Service")
+		return result.PermanentError
+	}
+
+	if LoadBalancerStatusEqual(old.Status.LoadBalancer, newObj.Status.LoadBalancer) {
+		return result.NoChange
+	}
+
+	err = PatchLoadBalancerStatus(old.Status.LoadBalancer, newObj.Status.LoadBalancer, func(pt types.PatchType, data []byte) error {
+		_, err := client.Patch(ctx, name, pt, data, metav1.PatchOptions{}, "status")
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to patch Service status")
+	}
+
+	return result.ClassifyError(err)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestLoadBalancerStatusEqual(t *testing.T) {
+	tests := map[string]struct {
+		l, r v1.LoadBalancerStatus
+		want bool
+	}{
+		"identical order is equal": {
+			l: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.1"}, {IP: "192.0.2.2"}}},
+			r: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.1"}, {IP: "192.0.2.2"}}},
+			want: true,
+		},
+		"reordered entries are equal": {
+			l: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.1"}, {IP: "192.0.2.2"}}},
+			r: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.2"}, {IP: "192.0.2.1"}}},
+			want: true,
+		},
+		"reordered mix of IPs and hostnames are equal": {
+			l: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.1"}, {Hostname: "lb.example.com"}}},
+			r: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{Hostname: "lb.example.com"}, {IP: "192.0.2.1"}}},
+			want: true,
+		},
+		"different length is not equal": {
+			l: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.1"}}},
+			r: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.1"}, {IP: "192.0.2.2"}}},
+			want: false,
+		},
+		"different addresses are not equal": {
+			l: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.1"}}},
+			r: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.2"}}},
+			want: false,
+		},
+		"both empty are equal": {
+			l:    v1.LoadBalancerStatus{},
+			r:    v1.LoadBalancerStatus{},
+			want: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := LoadBalancerStatusEqual(tc.l, tc.r); got != tc.want {
+				t.Fatalf("LoadBalancerStatusEqual() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1beta1"
+	networkingv1listers "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/projectcontour/contour/internal/status/result"
+)
+
+var ingressGVR = v1beta1.SchemeGroupVersion.WithResource("ingresses")
+var serviceGVR = v1.SchemeGroupVersion.WithResource("services")
+
+// ServiceStatusLoadBalancerWatcher implements ResourceEventHandler and
+// watches for changes to the status.loadBalancer field of the named
+// Service, publishing updates onto LBStatus.
+type ServiceStatusLoadBalancerWatcher struct {
+	ServiceName string
+	LBStatus    chan v1.LoadBalancerStatus
+}
+
+// OnAdd implements ResourceEventHandler.
+func (s *ServiceStatusLoadBalancerWatcher) OnAdd(obj interface{}) {
+	svc, ok := obj.(*v1.Service)
+	if !ok {
+		return
+	}
+
+	s.notify(svc)
+}
+
+// OnUpdate implements ResourceEventHandler.
+func (s *ServiceStatusLoadBalancerWatcher) OnUpdate(oldObj, newObj interface{}) {
+	svc, ok := newObj.(*v1.Service)
+	if !ok {
+		return
+	}
+
+	s.notify(svc)
+}
+
+// OnDelete implements ResourceEventHandler.
+func (s *ServiceStatusLoadBalancerWatcher) OnDelete(obj interface{}) {
+	svc, ok := obj.(*v1.Service)
+	if !ok {
+		return
+	}
+
+	if svc.Name != s.ServiceName {
+		return
+	}
+
+	s.LBStatus <- v1.LoadBalancerStatus{}
+}
+
+func (s *ServiceStatusLoadBalancerWatcher) notify(svc *v1.Service) {
+	if svc.Name != s.ServiceName {
+		return
+	}
+
+	s.LBStatus <- svc.Status.LoadBalancer
+}
+
+// StatusAddressUpdater observes informer OnAdd/OnUpdate events for
+// Ingress (and, in the future, other) objects and writes LBStatus onto
+// their status.loadBalancer field when the object's ingress class
+// matches, via StatusUpdater.
+//
+// Whether an Ingress is considered ours is decided by the first of these
+// that applies:
+//   - if it carries the "kubernetes.io/ingress.class" annotation, it is
+//     ours only if the annotation equals IngressClass;
+//   - else if it sets spec.ingressClassName, it is ours only if that names
+//     an IngressClass (resolved via IngressClassLister) whose
+//     spec.controller equals IngressClassControllerName;
+//   - else it is ours if IngressClass is empty, or if an IngressClass
+//     annotated "ingressclass.kubernetes.io/is-default-class" exists whose
+//     spec.controller equals IngressClassControllerName.
+//
+// LBStatus is sourced either from a ServiceStatusLoadBalancerWatcher
+// (--envoy-service-name, the default) or from a static list of
+// addresses (--envoy-service-http-address / --publish-address), in
+// which case it never changes after startup.
+//
+// If Queue is set, a sync that fails with a result.TransientError is
+// additionally re-queued by key so that a status.Controller running
+// against this updater (as its status.Syncer) retries it later with
+// exponential backoff, instead of the failure being silently dropped.
+type StatusAddressUpdater struct {
+	Logger                     logrus.FieldLogger
+	LBStatus                   v1.LoadBalancerStatus
+	IngressClass               string
+	IngressClassControllerName string
+	IngressClassLister         networkingv1listers.IngressClassLister
+	StatusUpdater              StatusUpdater
+	Queue                      workqueue.RateLimitingInterface
+
+	mu        sync.Mutex
+	ingresses map[string]*v1beta1.Ingress
+}
+
+// OnAdd implements ResourceEventHandler. See OnAddResult for a variant
+// that reports the outcome.
+func (iu *StatusAddressUpdater) OnAdd(obj interface{}) {
+	iu.OnAddResult(obj)
+}
+
+// OnUpdate implements ResourceEventHandler. See OnUpdateResult for a
+// variant that reports the outcome.
+func (iu *StatusAddressUpdater) OnUpdate(oldObj, newObj interface{}) {
+	iu.OnUpdateResult(oldObj, newObj)
+}
+
+// OnDelete implements ResourceEventHandler. Deletions require no status
+// update since the object no longer exists.
+func (iu *StatusAddressUpdater) OnDelete(obj interface{}) {
+	iu.OnDeleteResult(obj)
+
+	ing, ok := obj.(*v1beta1.Ingress)
+	if !ok {
+		return
+	}
+
+	iu.mu.Lock()
+	delete(iu.ingresses, key(ing))
+	iu.mu.Unlock()
+}
+
+// Sync implements status.Syncer. It resolves key back to the Ingress
+// cached by the most recent OnAdd/OnUpdate for it and re-runs the same
+// update that ran synchronously then, so that a status.Controller can
+// retry a result.TransientError against real object state.
+func (iu *StatusAddressUpdater) Sync(key string) result.Status {
+	iu.mu.Lock()
+	ing, ok := iu.ingresses[key]
+	iu.mu.Unlock()
+	if !ok {
+		return result.NotApplicable
+	}
+
+	return iu.updateIngress(ing)
+}
+
+// OnAddResult behaves like OnAdd, additionally reporting the outcome of
+// the sync so that a controller loop can apply differentiated backoff.
+func (iu *StatusAddressUpdater) OnAddResult(obj interface{}) result.Status {
+	return iu.onChange(obj)
+}
+
+// OnUpdateResult behaves like OnUpdate, additionally reporting the
+// outcome of the sync.
+func (iu *StatusAddressUpdater) OnUpdateResult(oldObj, newObj interface{}) result.Status {
+	return iu.onChange(newObj)
+}
+
+// OnDeleteResult behaves like OnDelete, additionally reporting the
+// outcome of the sync. Deletions are always NotApplicable since there is
+// no object left to update.
+func (iu *StatusAddressUpdater) OnDeleteResult(obj interface{}) result.Status {
+	return result.NotApplicable
+}
+
+func (iu *StatusAddressUpdater) onChange(obj interface{}) result.Status {
+	switch o := obj.(type) {
+	case *v1beta1.Ingress:
+		iu.mu.Lock()
+		if iu.ingresses == nil {
+			iu.ingresses = make(map[string]*v1beta1.Ingress)
+		}
+		iu.ingresses[key(o)] = o
+		iu.mu.Unlock()
+
+		status := iu.updateIngress(o)
+		if status == result.TransientError && iu.Queue != nil {
+			iu.Queue.AddRateLimited(key(o))
+		}
+
+		return status
+	default:
+		iu.Logger.WithField("object", obj).Debug("unsupported object type for status update")
+		return result.NotApplicable
+	}
+}
+
+func (iu *StatusAddressUpdater) updateIngress(ing *v1beta1.Ingress) result.Status {
+	if !iu.matchesIngressClass(ing) {
+		iu.Logger.WithField("name", ing.Name).WithField("namespace", ing.Namespace).
+			Debug("unmatched ingress class, skipping")
+		return result.NotApplicable
+	}
+
+	if LoadBalancerStatusEqual(ing.Status.LoadBalancer, iu.LBStatus) {
+		return result.NoChange
+	}
+
+	lbstatus := iu.LBStatus
+	return iu.StatusUpdater.Update(ing.Name, ing.Namespace, ingressGVR, StatusMutatorFunc(func(old interface{}) interface{} {
+		o, ok := old.(*v1beta1.Ingress)
+		if !ok {
+			return old
+		}
+
+		updated := o.DeepCopy()
+		updated.Status.LoadBalancer = lbstatus
+		return updated
+	}))
+}
+
+func (iu *StatusAddressUpdater) matchesIngressClass(ing *v1beta1.Ingress) bool {
+	return ingressMatchesClass(ing, iu.IngressClass, iu.controllerName(), iu.IngressClassLister)
+}
+
+func (iu *StatusAddressUpdater) controllerName() string {
+	return ingressClassControllerName(iu.IngressClassControllerName)
+}
+
+// ParsePublishAddresses parses the comma-separated list of IPs and/or
+// hostnames passed to --publish-address into the LoadBalancerStatus that
+// should be written onto objects managed by a StatusAddressUpdater,
+// bypassing the need for a Service of type LoadBalancer to exist.
+func ParsePublishAddresses(addresses string) v1.LoadBalancerStatus {
+	var lbs v1.LoadBalancerStatus
+
+	for _, a := range strings.Split(addresses, ",") {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+
+		if ip := net.ParseIP(a); ip != nil {
+			lbs.Ingress = append(lbs.Ingress, v1.LoadBalancerIngress{IP: a})
+			continue
+		}
+
+		lbs.Ingress = append(lbs.Ingress, v1.LoadBalancerIngress{Hostname: a})
+	}
+
+	return lbs
+}
+
+// ValidateStatusAddressFlags checks that --publish-service and
+// --publish-address were not both set, and that at least one of them is
+// set when statusUpdatesRequested is true. It is intended to be called
+// from cmd/contour/serve.go before any informers are started, so that a
+// misconfiguration fails fast at startup instead of silently leaving
+// Ingress status unset.
+func ValidateStatusAddressFlags(publishService, publishAddress string, statusUpdatesRequested bool) error {
+	switch {
+	case publishService != "" && publishAddress != "":
+		return fmt.Errorf("cannot set both --publish-service and --publish-address")
+	case publishService == "" && publishAddress == "" && statusUpdatesRequested:
+		return fmt.Errorf("one of --publish-service or --publish-address must be set")
+	default:
+		return nil
+	}
+}
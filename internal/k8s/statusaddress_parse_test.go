@@ -0,0 +1,130 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestParsePublishAddresses(t *testing.T) {
+	tests := map[string]struct {
+		addresses string
+		want      v1.LoadBalancerStatus
+	}{
+		"single IP": {
+			addresses: "192.0.2.1",
+			want: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.1"}},
+			},
+		},
+		"single hostname": {
+			addresses: "lb.projectcontour.io",
+			want: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{{Hostname: "lb.projectcontour.io"}},
+			},
+		},
+		"mixed IPv4, IPv6 and hostname": {
+			addresses: "192.0.2.1,2001:db8::1,lb.projectcontour.io",
+			want: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{
+					{IP: "192.0.2.1"},
+					{IP: "2001:db8::1"},
+					{Hostname: "lb.projectcontour.io"},
+				},
+			},
+		},
+		"whitespace around entries is trimmed": {
+			addresses: " 192.0.2.1 , lb.projectcontour.io ",
+			want: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{
+					{IP: "192.0.2.1"},
+					{Hostname: "lb.projectcontour.io"},
+				},
+			},
+		},
+		"empty segments from repeated or trailing commas are skipped": {
+			addresses: "192.0.2.1,,lb.projectcontour.io,",
+			want: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{
+					{IP: "192.0.2.1"},
+					{Hostname: "lb.projectcontour.io"},
+				},
+			},
+		},
+		"empty string produces no entries": {
+			addresses: "",
+			want:      v1.LoadBalancerStatus{},
+		},
+		"whitespace-only string produces no entries": {
+			addresses: "   ",
+			want:      v1.LoadBalancerStatus{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ParsePublishAddresses(tc.addresses)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("ParsePublishAddresses(%q) mismatch (-want +got):\n%s", tc.addresses, diff)
+			}
+		})
+	}
+}
+
+func TestValidateStatusAddressFlags(t *testing.T) {
+	tests := map[string]struct {
+		publishService         string
+		publishAddress         string
+		statusUpdatesRequested bool
+		wantErr                bool
+	}{
+		"both set is an error": {
+			publishService:         "projectcontour/envoy",
+			publishAddress:         "192.0.2.1",
+			statusUpdatesRequested: true,
+			wantErr:                true,
+		},
+		"neither set with status updates requested is an error": {
+			statusUpdatesRequested: true,
+			wantErr:                true,
+		},
+		"neither set without status updates requested is fine": {
+			statusUpdatesRequested: false,
+			wantErr:                false,
+		},
+		"only publish-service set is fine": {
+			publishService:         "projectcontour/envoy",
+			statusUpdatesRequested: true,
+			wantErr:                false,
+		},
+		"only publish-address set is fine": {
+			publishAddress:         "192.0.2.1",
+			statusUpdatesRequested: true,
+			wantErr:                false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateStatusAddressFlags(tc.publishService, tc.publishAddress, tc.statusUpdatesRequested)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ValidateStatusAddressFlags(%q, %q, %v) error = %v, wantErr = %v",
+					tc.publishService, tc.publishAddress, tc.statusUpdatesRequested, err, tc.wantErr)
+			}
+		})
+	}
+}
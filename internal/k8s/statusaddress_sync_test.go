@@ -0,0 +1,86 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/projectcontour/contour/internal/status"
+	"github.com/projectcontour/contour/internal/status/result"
+)
+
+// flakyStatusUpdater fails the first N calls with a TransientError before
+// succeeding, so tests can exercise the retry path of a real
+// StatusAddressUpdater.
+type flakyStatusUpdater struct {
+	failuresLeft int
+	calls        int
+}
+
+func (f *flakyStatusUpdater) Update(name, namespace string, gvr schema.GroupVersionResource, mutator StatusMutator) result.Status {
+	f.calls++
+	if f.failuresLeft > 0 {
+		f.failuresLeft--
+		return result.TransientError
+	}
+
+	return result.Success
+}
+
+// TestStatusAddressUpdaterSyncRetriesRequeuedKey verifies that
+// StatusAddressUpdater is a real status.Syncer: a TransientError from its
+// synchronous OnAdd/OnUpdate path is rate-limit-requeued by key, and
+// replaying that key through Sync (as a status.Controller's
+// processNextItem eventually would, once the backoff elapses) resolves
+// it back to the same cached Ingress and retries the update.
+func TestStatusAddressUpdaterSyncRetriesRequeuedKey(t *testing.T) {
+	updater := &flakyStatusUpdater{failuresLeft: 1}
+
+	iu := &StatusAddressUpdater{
+		Logger:        logrus.New(),
+		LBStatus:      v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "127.0.0.1"}}},
+		StatusUpdater: updater,
+		Queue:         status.NewQueue(t.Name()),
+	}
+	defer iu.Queue.ShutDown()
+
+	var _ status.Syncer = iu // StatusAddressUpdater must satisfy status.Syncer.
+
+	ing := simpleIngressGenerator("retryme", "", v1.LoadBalancerStatus{})
+	key := ing.Namespace + "/" + ing.Name
+
+	got := iu.OnAddResult(ing)
+	if got != result.TransientError {
+		t.Fatalf("OnAddResult() = %v, want %v", got, result.TransientError)
+	}
+
+	// AddRateLimited increments the item's requeue count immediately, even
+	// though delivery back onto the queue is delayed by the backoff; see
+	// the identical caveat in internal/status/controller_test.go.
+	if iu.Queue.NumRequeues(key) == 0 {
+		t.Fatalf("expected the failed key to be rate-limit-requeued for retry")
+	}
+
+	if got := iu.Sync(key); got != result.Success {
+		t.Fatalf("Sync(%q) = %v, want %v", key, got, result.Success)
+	}
+
+	if updater.calls != 2 {
+		t.Fatalf("expected StatusUpdater.Update to be called twice (fail, then retry), got %d", updater.calls)
+	}
+}
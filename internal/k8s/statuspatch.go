@@ -0,0 +1,63 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"encoding/json"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// loadBalancerStatusDoc is the minimal shape shared by IngressStatus and
+// ServiceStatus, used to build a status-only strategic-merge patch
+// without needing to round-trip the whole object.
+type loadBalancerStatusDoc struct {
+	Status struct {
+		LoadBalancer v1.LoadBalancerStatus `json:"loadBalancer"`
+	} `json:"status"`
+}
+
+// PatchLoadBalancerStatus builds a two-document strategic-merge patch
+// that moves status.loadBalancer from old to new, and hands the
+// resulting bytes to patch to apply against the status subresource.
+// patch is not called, and nil is returned, if old and new are already
+// equal, so that callers never issue a no-op Patch call.
+func PatchLoadBalancerStatus(old, new v1.LoadBalancerStatus, patch func(pt types.PatchType, data []byte) error) error {
+	if LoadBalancerStatusEqual(old, new) {
+		return nil
+	}
+
+	var oldDoc, newDoc loadBalancerStatusDoc
+	oldDoc.Status.LoadBalancer = old
+	newDoc.Status.LoadBalancer = new
+
+	oldData, err := json.Marshal(oldDoc)
+	if err != nil {
+		return err
+	}
+
+	newData, err := json.Marshal(newDoc)
+	if err != nil {
+		return err
+	}
+
+	patchData, err := strategicpatch.CreateTwoWayMergePatch(oldData, newData, loadBalancerStatusDoc{})
+	if err != nil {
+		return err
+	}
+
+	return patch(types.StrategicMergePatchType, patchData)
+}
@@ -0,0 +1,118 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"encoding/json"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPatchLoadBalancerStatusNoChange(t *testing.T) {
+	status := v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "127.0.0.1"}}}
+
+	called := false
+	err := PatchLoadBalancerStatus(status, status, func(pt types.PatchType, data []byte) error {
+		called = true
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if called {
+		t.Fatal("patch func should not be called when old and new are equal")
+	}
+}
+
+func TestPatchLoadBalancerStatusIsMinimal(t *testing.T) {
+	old := v1.LoadBalancerStatus{}
+	new := v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "127.0.0.1"}}}
+
+	var gotType types.PatchType
+	var gotData []byte
+
+	err := PatchLoadBalancerStatus(old, new, func(pt types.PatchType, data []byte) error {
+		gotType = pt
+		gotData = data
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotType != types.StrategicMergePatchType {
+		t.Fatalf("expected a strategic merge patch, got %s", gotType)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(gotData, &doc); err != nil {
+		t.Fatalf("patch body is not valid JSON: %v", err)
+	}
+
+	if len(doc) != 1 {
+		t.Fatalf("expected patch to touch only status, got %v", doc)
+	}
+
+	status, ok := doc["status"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a status field, got %v", doc)
+	}
+
+	if len(status) != 1 {
+		t.Fatalf("expected status to contain only loadBalancer, got %v", status)
+	}
+
+	if _, ok := status["loadBalancer"]; !ok {
+		t.Fatalf("expected status.loadBalancer, got %v", status)
+	}
+}
+
+func TestStatusUpdateCacherPatchLeavesOtherFieldsUntouched(t *testing.T) {
+	ingressGVRForTest := v1beta1.SchemeGroupVersion.WithResource("ingresses")
+
+	pre := simpleIngressGenerator("concurrent", "", v1.LoadBalancerStatus{})
+	pre.Spec.Backend = &v1beta1.IngressBackend{ServiceName: "concurrently-edited"}
+
+	suc := StatusUpdateCacher{}
+	if !suc.AddObject("concurrent", "concurrent", ingressGVRForTest, pre) {
+		t.Fatal("unable to add object to cache")
+	}
+
+	newStatus := v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "127.0.0.1"}}}
+	suc.Update("concurrent", "concurrent", ingressGVRForTest, StatusMutatorFunc(func(old interface{}) interface{} {
+		o := old.(*v1beta1.Ingress).DeepCopy()
+		o.Status.LoadBalancer = newStatus
+		return o
+	}))
+
+	patch := suc.LastPatch("concurrent", "concurrent", ingressGVRForTest)
+	if patch == nil {
+		t.Fatal("expected a recorded patch")
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(patch, &doc); err != nil {
+		t.Fatalf("patch body is not valid JSON: %v", err)
+	}
+
+	if _, ok := doc["spec"]; ok {
+		t.Fatalf("patch must not touch spec, a concurrent edit there must survive a real apply: %v", doc)
+	}
+}
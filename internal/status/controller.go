@@ -0,0 +1,116 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package status drives a workqueue of status sync keys, applying
+// differentiated backoff and event emission based on the result.Status
+// each sync reports.
+package status
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/projectcontour/contour/internal/status/result"
+)
+
+const (
+	minRetryDelay = 250 * time.Millisecond
+	maxRetryDelay = 30 * time.Second
+)
+
+// Syncer reconciles the object named by key ("namespace/name") and
+// reports what happened.
+type Syncer interface {
+	Sync(key string) result.Status
+}
+
+// NewQueue returns a workqueue configured with the exponential backoff
+// (250ms-30s) that Controller expects for result.TransientError.
+func NewQueue(name string) workqueue.RateLimitingInterface {
+	return workqueue.NewNamedRateLimitingQueue(
+		workqueue.NewItemExponentialFailureRateLimiter(minRetryDelay, maxRetryDelay),
+		name,
+	)
+}
+
+// Controller drains Queue through Syncer, one key at a time, applying a
+// retry/event policy based on each sync's result.Status:
+//   - NoChange, NotApplicable: forgotten silently; no event, no re-queue.
+//   - Success: forgotten, and a SyncSuccess event recorded.
+//   - TransientError: re-queued with exponential backoff, no event.
+//   - PermanentError: forgotten, and a SyncFailure warning event recorded.
+type Controller struct {
+	Log      logrus.FieldLogger
+	Queue    workqueue.RateLimitingInterface
+	Syncer   Syncer
+	Recorder record.EventRecorder
+}
+
+// Run processes keys from Queue until it is shut down.
+func (c *Controller) Run() {
+	for c.processNextItem() {
+	}
+}
+
+func (c *Controller) processNextItem() bool {
+	key, quit := c.Queue.Get()
+	if quit {
+		return false
+	}
+	defer c.Queue.Done(key)
+
+	switch status := c.Syncer.Sync(key.(string)); status {
+	case result.NoChange, result.NotApplicable:
+		c.Queue.Forget(key)
+	case result.Success:
+		c.Queue.Forget(key)
+		c.record(key.(string), v1.EventTypeNormal, "SyncSuccess", "successfully synced status")
+	case result.TransientError:
+		c.Log.WithField("key", key).Debug("transient error syncing status, retrying")
+		c.Queue.AddRateLimited(key)
+	case result.PermanentError:
+		c.Queue.Forget(key)
+		c.record(key.(string), v1.EventTypeWarning, "SyncFailure", "permanent error syncing status, not retrying")
+	default:
+		c.Log.WithField("key", key).WithField("status", status).Error("unknown status, forgetting key")
+		c.Queue.Forget(key)
+	}
+
+	return true
+}
+
+// record emits an event against the object named by key, if a Recorder
+// is configured.
+func (c *Controller) record(key, eventType, reason, message string) {
+	if c.Recorder == nil {
+		return
+	}
+
+	namespace, name := splitKey(key)
+	ref := &v1.ObjectReference{Namespace: namespace, Name: name}
+	c.Recorder.Event(ref, eventType, reason, message)
+}
+
+func splitKey(key string) (namespace, name string) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return "", key
+	}
+
+	return parts[0], parts[1]
+}
@@ -0,0 +1,90 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/projectcontour/contour/internal/status/result"
+)
+
+type fixedSyncer struct {
+	status result.Status
+	calls  int
+}
+
+func (f *fixedSyncer) Sync(key string) result.Status {
+	f.calls++
+	return f.status
+}
+
+func TestControllerProcessNextItemDispatch(t *testing.T) {
+	tests := map[string]struct {
+		status        result.Status
+		wantRequeued  bool
+		wantNumEvents int
+	}{
+		"no change is forgotten silently":         {result.NoChange, false, 0},
+		"not applicable is forgotten silently":    {result.NotApplicable, false, 0},
+		"success is forgotten with an event":      {result.Success, false, 1},
+		"transient error is requeued, no event":   {result.TransientError, true, 0},
+		"permanent error is forgotten with event": {result.PermanentError, false, 1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			syncer := &fixedSyncer{status: tc.status}
+			recorder := record.NewFakeRecorder(1)
+			queue := NewQueue(name)
+			defer queue.ShutDown()
+
+			c := &Controller{
+				Log:      logrus.New(),
+				Queue:    queue,
+				Syncer:   syncer,
+				Recorder: recorder,
+			}
+
+			queue.Add("default/test")
+
+			if !c.processNextItem() {
+				t.Fatal("expected processNextItem to process the queued key")
+			}
+
+			if syncer.calls != 1 {
+				t.Fatalf("expected Sync to be called once, got %d", syncer.calls)
+			}
+
+			// AddRateLimited increments the item's requeue count immediately,
+			// even though delivery back onto the queue is delayed.
+			if got := queue.NumRequeues("default/test") > 0; got != tc.wantRequeued {
+				t.Fatalf("NumRequeues() > 0 = %v, wantRequeued = %v", got, tc.wantRequeued)
+			}
+
+			select {
+			case <-recorder.Events:
+				if tc.wantNumEvents == 0 {
+					t.Fatal("did not expect an event to be recorded")
+				}
+			default:
+				if tc.wantNumEvents > 0 {
+					t.Fatal("expected an event to be recorded")
+				}
+			}
+		})
+	}
+}
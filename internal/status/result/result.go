@@ -0,0 +1,63 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package result classifies the outcome of a single status sync, so
+// that callers can apply differentiated retry and event policies
+// instead of treating every non-nil error the same way.
+package result
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Status describes the outcome of a sync attempt.
+type Status string
+
+const (
+	// NoChange means the object was already in the desired state; no
+	// write was attempted.
+	NoChange Status = "NoChange"
+
+	// Success means a write was attempted and succeeded.
+	Success Status = "Success"
+
+	// TransientError means the write failed for a reason expected to
+	// clear on its own (a conflict, timeout, or rate limit); the sync
+	// should be retried with backoff.
+	TransientError Status = "TransientError"
+
+	// PermanentError means the write failed for a reason that retrying
+	// will not fix (the object or request was rejected outright).
+	PermanentError Status = "PermanentError"
+
+	// NotApplicable means the object is not ours to sync, for example
+	// because its ingress class does not match.
+	NotApplicable Status = "NotApplicable"
+)
+
+// ClassifyError maps an error returned from a Kubernetes API write into
+// a Status. A nil error classifies as Success.
+func ClassifyError(err error) Status {
+	switch {
+	case err == nil:
+		return Success
+	case apierrors.IsConflict(err), apierrors.IsServerTimeout(err), apierrors.IsTooManyRequests(err):
+		return TransientError
+	case apierrors.IsInvalid(err), apierrors.IsForbidden(err):
+		return PermanentError
+	default:
+		// Err on the side of retrying errors we don't recognize, since a
+		// permanent misclassification silently drops real work.
+		return TransientError
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright © 2020 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package result
+
+import (
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassifyError(t *testing.T) {
+	gr := schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}
+
+	tests := map[string]struct {
+		err  error
+		want Status
+	}{
+		"nil error is success":               {nil, Success},
+		"conflict is transient":              {apierrors.NewConflict(gr, "name", errors.New("conflict")), TransientError},
+		"server timeout is transient":        {apierrors.NewServerTimeout(gr, "patch", 0), TransientError},
+		"too many requests is transient":     {apierrors.NewTooManyRequests("slow down", 0), TransientError},
+		"invalid is permanent":               {apierrors.NewInvalid(schema.GroupKind{Group: gr.Group, Kind: "Ingress"}, "name", nil), PermanentError},
+		"forbidden is permanent":             {apierrors.NewForbidden(gr, "name", errors.New("forbidden")), PermanentError},
+		"unrecognized error is transient":    {errors.New("boom"), TransientError},
+		"not found is not specially handled": {apierrors.NewNotFound(gr, "name"), TransientError},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := ClassifyError(tc.err); got != tc.want {
+				t.Fatalf("ClassifyError() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}